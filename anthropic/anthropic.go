@@ -0,0 +1,396 @@
+// Package anthropic provides a perplexity.Completer implementation backed by
+// the Anthropic Messages API, so that code written against the perplexity
+// package's neutral types can be pointed at Claude instead of (or in
+// addition to) Perplexity itself.
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emmaly/perplexity"
+)
+
+// DefaultBaseURL is the default base URL for the Anthropic API.
+const DefaultBaseURL = "https://api.anthropic.com/v1"
+
+// DefaultAnthropicVersion is the value sent in the `anthropic-version`
+// header when ClientOptions.AnthropicVersion is left empty.
+const DefaultAnthropicVersion = "2023-06-01"
+
+// DefaultMaxTokens is used when a ChatCompletionRequest does not specify
+// MaxTokens, which Anthropic requires on every request.
+const DefaultMaxTokens = 1024
+
+// Client is a perplexity.Completer backed by the Anthropic Messages API.
+type Client struct {
+	token            string
+	client           *http.Client
+	baseURL          string
+	anthropicVersion string
+}
+
+var _ perplexity.Completer = (*Client)(nil)
+
+// ClientOptions represents options for configuring a new Anthropic API client.
+type ClientOptions struct {
+	// HTTPClient is an optional *http.Client to use for requests.
+	HTTPClient *http.Client
+
+	// BaseURL is the base URL for the Anthropic API.
+	// If empty, `DefaultBaseURL` is used.
+	BaseURL string
+
+	// AnthropicVersion is sent as the `anthropic-version` header.
+	// If empty, `DefaultAnthropicVersion` is used.
+	AnthropicVersion string
+}
+
+// NewClient creates a new Anthropic API client with the given API key.
+// Optionally, you can pass a custom *http.Client to override default settings.
+// If httpClient is nil, a default client with reasonable timeouts is used.
+func NewClient(token string, options *ClientOptions) *Client {
+	var httpClient *http.Client
+	var anthropicVersion string
+	if options != nil {
+		httpClient = options.HTTPClient
+		anthropicVersion = options.AnthropicVersion
+	}
+
+	if httpClient == nil {
+		transport := &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 300 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}
+
+		httpClient = &http.Client{
+			Transport: transport,
+		}
+	}
+
+	baseURL := DefaultBaseURL
+	if options != nil && options.BaseURL != "" {
+		baseURL = options.BaseURL
+	}
+
+	if anthropicVersion == "" {
+		anthropicVersion = DefaultAnthropicVersion
+	}
+
+	return &Client{
+		token:            token,
+		client:           httpClient,
+		baseURL:          baseURL,
+		anthropicVersion: anthropicVersion,
+	}
+}
+
+// wireMessage is Anthropic's on-the-wire message shape. Anthropic only
+// recognizes "user" and "assistant" roles; system prompts are sent via the
+// separate top-level System field instead.
+type wireMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// wireRequest is Anthropic's on-the-wire Messages API request shape.
+type wireRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system,omitempty"`
+	Messages    []wireMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature,omitempty"`
+	TopP        float64       `json:"top_p,omitempty"`
+	TopK        int           `json:"top_k,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+type wireContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type wireUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// wireResponse is Anthropic's on-the-wire Messages API response shape.
+type wireResponse struct {
+	ID         string             `json:"id"`
+	Model      string             `json:"model"`
+	Type       string             `json:"type"`
+	Role       string             `json:"role"`
+	Content    []wireContentBlock `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      wireUsage          `json:"usage"`
+}
+
+type wireError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// wireStreamEvent covers the fields used across Anthropic's several SSE
+// event types (message_start, content_block_delta, message_delta, message_stop).
+type wireStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Message wireResponse `json:"message"`
+	Usage   wireUsage    `json:"usage"`
+}
+
+func toWireRequest(req perplexity.ChatCompletionRequest) wireRequest {
+	var system strings.Builder
+	messages := make([]wireMessage, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		if m.Role == perplexity.MessageRoleSystem {
+			if system.Len() > 0 {
+				system.WriteString("\n\n")
+			}
+			system.WriteString(m.Content)
+			continue
+		}
+		messages = append(messages, wireMessage{Role: string(m.Role), Content: m.Content})
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = DefaultMaxTokens
+	}
+
+	return wireRequest{
+		Model:       string(req.Model),
+		System:      system.String(),
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		TopK:        req.TopK,
+		Stream:      req.Stream != nil,
+	}
+}
+
+// rejectTools fails fast when req asks for tool calling: unlike
+// perplexity.Client's client-side enforcement, this Completer doesn't
+// translate Tools/ToolCalls into the Anthropic Messages API's native tool_use
+// content blocks, so silently dropping them would mis-translate the
+// conversation rather than honor it.
+func rejectTools(req perplexity.ChatCompletionRequest) error {
+	if len(req.Tools) > 0 {
+		return errors.New("anthropic: tool calling is not supported by this Completer")
+	}
+	for _, m := range req.Messages {
+		if m.Role == perplexity.MessageRoleTool || len(m.ToolCalls) > 0 {
+			return errors.New("anthropic: tool-call messages are not supported by this Completer")
+		}
+	}
+	return nil
+}
+
+func contentText(blocks []wireContentBlock) string {
+	var text strings.Builder
+	for _, b := range blocks {
+		if b.Type == "text" {
+			text.WriteString(b.Text)
+		}
+	}
+	return text.String()
+}
+
+func fromWireResponse(resp wireResponse) *perplexity.ChatCompletionResponse {
+	return &perplexity.ChatCompletionResponse{
+		ID:    resp.ID,
+		Model: resp.Model,
+		Choices: []perplexity.Choice{
+			{
+				FinishReason: perplexity.FinishReason(resp.StopReason),
+				Message: perplexity.Message{
+					Role:    perplexity.MessageRoleAssistant,
+					Content: contentText(resp.Content),
+				},
+			},
+		},
+		Usage: perplexity.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
+
+// ChatCompletion sends a chat completion request to the Anthropic Messages
+// API, translating between perplexity's neutral types and Anthropic's wire
+// format.
+func (c *Client) ChatCompletion(ctx context.Context, req perplexity.ChatCompletionRequest) (*perplexity.ChatCompletionResponse, error) {
+	url := c.baseURL + "/messages"
+
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if len(req.Messages) == 0 {
+		return nil, errors.New("at least one message is required")
+	}
+	if req.Messages[len(req.Messages)-1].Role != perplexity.MessageRoleUser {
+		return nil, errors.New("the last message must be from the user")
+	}
+	if err := rejectTools(req); err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(toWireRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("x-api-key", c.token)
+	httpReq.Header.Set("anthropic-version", c.anthropicVersion)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		var e wireError
+		if err := json.Unmarshal(bodyBytes, &e); err == nil && e.Error.Message != "" {
+			return nil, fmt.Errorf("API error: %s", e.Error.Message)
+		}
+		return nil, fmt.Errorf("unexpected status code: %s", res.Status)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "text/event-stream" || strings.HasPrefix(contentType, "text/event-stream;") {
+		if req.Stream == nil {
+			return nil, errors.New("streaming response received but no stream handler provided")
+		}
+		return c.handleStreamingResponse(res, req.Stream)
+	}
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var response wireResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, err
+	}
+
+	return fromWireResponse(response), nil
+}
+
+// handleStreamingResponse handles streaming responses from the Anthropic
+// Messages API. It reads the Server-Sent Events (SSE) from the response,
+// calling onUpdate with each message_start, content_block_delta and
+// message_delta event translated into perplexity's neutral
+// ChatCompletionResponse shape (other event types are not forwarded), while itself accumulating content block
+// deltas into a single fully-assembled ChatCompletionResponse, which it
+// returns once the stream completes so the caller doesn't have to
+// concatenate deltas itself.
+func (c *Client) handleStreamingResponse(res *http.Response, onUpdate perplexity.OnUpdateHandler) (*perplexity.ChatCompletionResponse, error) {
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+
+	var id, model string
+	var content strings.Builder
+	var finishReason perplexity.FinishReason
+	usage := perplexity.Usage{}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" || !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		jsonData := line[len("data: "):]
+
+		var event wireStreamEvent
+		if err := json.Unmarshal([]byte(jsonData), &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal streaming event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			id = event.Message.ID
+			model = event.Message.Model
+			usage.PromptTokens = event.Message.Usage.InputTokens
+			onUpdate(perplexity.ChatCompletionResponse{ID: id, Model: model})
+		case "content_block_delta":
+			content.WriteString(event.Delta.Text)
+			onUpdate(perplexity.ChatCompletionResponse{
+				ID:    id,
+				Model: model,
+				Choices: []perplexity.Choice{
+					{Delta: perplexity.Message{Role: perplexity.MessageRoleAssistant, Content: event.Delta.Text}},
+				},
+			})
+		case "message_delta":
+			finishReason = perplexity.FinishReason(event.Delta.StopReason)
+			usage.CompletionTokens = event.Usage.OutputTokens
+			onUpdate(perplexity.ChatCompletionResponse{
+				ID:    id,
+				Model: model,
+				Choices: []perplexity.Choice{
+					{FinishReason: finishReason},
+				},
+				Usage: perplexity.Usage{
+					CompletionTokens: event.Usage.OutputTokens,
+				},
+			})
+		case "message_stop":
+			// No payload beyond the event type itself.
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading streaming response: %w", err)
+	}
+
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return &perplexity.ChatCompletionResponse{
+		ID:    id,
+		Model: model,
+		Choices: []perplexity.Choice{
+			{
+				FinishReason: finishReason,
+				Message: perplexity.Message{
+					Role:    perplexity.MessageRoleAssistant,
+					Content: content.String(),
+				},
+			},
+		},
+		Usage: usage,
+	}, nil
+}
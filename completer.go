@@ -0,0 +1,22 @@
+package perplexity
+
+import "context"
+
+// Completer is implemented by any backend capable of producing a chat
+// completion from a ChatCompletionRequest. Client implements Completer for
+// the Perplexity API itself; sibling packages (openai, anthropic, mistral)
+// provide Completer implementations for their respective APIs, translating
+// to and from this same neutral request/response schema at their own
+// provider boundary. This lets callers swap backends by changing which
+// Completer they construct, or combine several behind a MultiCompleter.
+//
+// Tool-calling support varies by Completer: Client emulates it client-side
+// for every provider since Perplexity has no native notion of it; openai
+// translates ChatCompletionRequest.Tools/Message.ToolCalls into OpenAI's
+// native tool-calling wire format; anthropic and mistral don't yet translate
+// tools at all and return an error if Tools or a tool-call message is set.
+type Completer interface {
+	ChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error)
+}
+
+var _ Completer = (*Client)(nil)
@@ -0,0 +1,112 @@
+package perplexity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError represents a non-200 response from the Perplexity API. Callers
+// can inspect StatusCode/Code/Type directly, or use errors.Is against the
+// sentinel errors below to branch on common failure modes.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code is the provider's machine-readable error code, if the response
+	// body included one.
+	Code string
+
+	// Message is the human-readable error message from the response body.
+	Message string
+
+	// Type is the provider's error category, if the response body included
+	// one (e.g. "invalid_request_error").
+	Type string
+
+	// RequestID is the value of the response's X-Request-Id header, if
+	// present, to make server-side debugging tractable.
+	RequestID string
+
+	// Body is the raw response body, for callers that need more than the
+	// fields above surface.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "perplexity: API error (status %d", e.StatusCode)
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, ", request %s", e.RequestID)
+	}
+	b.WriteString("): ")
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// Is reports whether target is one of the sentinel errors below and this
+// APIError matches it, so that `errors.Is(err, ErrRateLimited)` and similar
+// work without the caller needing to inspect StatusCode/Code itself.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrAuthentication:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrContextLengthExceeded:
+		return e.Code == "context_length_exceeded" || strings.Contains(strings.ToLower(e.Message), "context length")
+	case ErrInvalidModel:
+		return e.Code == "invalid_model" || e.Code == "model_not_found" || strings.Contains(strings.ToLower(e.Message), "invalid model")
+	default:
+		return false
+	}
+}
+
+// Sentinel errors for the common failure modes of ChatCompletion. Match
+// against them with errors.Is; the underlying error is always an *APIError.
+var (
+	ErrRateLimited           = errors.New("perplexity: rate limited")
+	ErrContextLengthExceeded = errors.New("perplexity: context length exceeded")
+	ErrInvalidModel          = errors.New("perplexity: invalid model")
+	ErrAuthentication        = errors.New("perplexity: authentication failed")
+)
+
+// wireAPIErrorBody is the shape of an OpenAI-style error body:
+// {"error": {"message": "...", "type": "...", "code": "..."}}.
+type wireAPIErrorBody struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// parseAPIError builds an APIError from a non-200 HTTP response and its
+// already-read body, handling both the nested wireAPIErrorBody shape and
+// the flatter `{"error": "..."}` shape some Perplexity endpoints use.
+func parseAPIError(res *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get("X-Request-Id"),
+		Body:       body,
+	}
+
+	var nested wireAPIErrorBody
+	if err := json.Unmarshal(body, &nested); err == nil && nested.Error.Message != "" {
+		apiErr.Message = nested.Error.Message
+		apiErr.Type = nested.Error.Type
+		apiErr.Code = nested.Error.Code
+		return apiErr
+	}
+
+	var flat apiError
+	if err := json.Unmarshal(body, &flat); err == nil && flat.Error != "" {
+		apiErr.Message = flat.Error
+		return apiErr
+	}
+
+	apiErr.Message = res.Status
+	return apiErr
+}
@@ -0,0 +1,116 @@
+package perplexity
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorIsSentinels(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		is   error
+		not  []error
+	}{
+		{
+			name: "rate limited",
+			err:  &APIError{StatusCode: http.StatusTooManyRequests},
+			is:   ErrRateLimited,
+			not:  []error{ErrAuthentication, ErrContextLengthExceeded, ErrInvalidModel},
+		},
+		{
+			name: "unauthorized",
+			err:  &APIError{StatusCode: http.StatusUnauthorized},
+			is:   ErrAuthentication,
+			not:  []error{ErrRateLimited, ErrContextLengthExceeded, ErrInvalidModel},
+		},
+		{
+			name: "forbidden",
+			err:  &APIError{StatusCode: http.StatusForbidden},
+			is:   ErrAuthentication,
+		},
+		{
+			name: "context length exceeded by code",
+			err:  &APIError{StatusCode: http.StatusBadRequest, Code: "context_length_exceeded"},
+			is:   ErrContextLengthExceeded,
+			not:  []error{ErrInvalidModel},
+		},
+		{
+			name: "context length exceeded by message",
+			err:  &APIError{StatusCode: http.StatusBadRequest, Message: "Context length of 4096 exceeded"},
+			is:   ErrContextLengthExceeded,
+		},
+		{
+			name: "invalid model by code",
+			err:  &APIError{StatusCode: http.StatusBadRequest, Code: "model_not_found"},
+			is:   ErrInvalidModel,
+			not:  []error{ErrContextLengthExceeded},
+		},
+		{
+			name: "invalid model by message",
+			err:  &APIError{StatusCode: http.StatusBadRequest, Message: "Invalid model specified"},
+			is:   ErrInvalidModel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.is) {
+				t.Errorf("expected errors.Is(err, %v) to be true", tt.is)
+			}
+			for _, n := range tt.not {
+				if errors.Is(tt.err, n) {
+					t.Errorf("expected errors.Is(err, %v) to be false", n)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAPIErrorNestedBody(t *testing.T) {
+	body := []byte(`{"error":{"message":"invalid request","type":"invalid_request_error","code":"bad_param"}}`)
+	res := &httptest.ResponseRecorder{Code: http.StatusBadRequest, HeaderMap: http.Header{}}
+	res.Header().Set("X-Request-Id", "req-123")
+
+	apiErr := parseAPIError(res.Result(), body)
+
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", apiErr.StatusCode, http.StatusBadRequest)
+	}
+	if apiErr.RequestID != "req-123" {
+		t.Errorf("got request ID %q, want %q", apiErr.RequestID, "req-123")
+	}
+	if apiErr.Message != "invalid request" {
+		t.Errorf("got message %q, want %q", apiErr.Message, "invalid request")
+	}
+	if apiErr.Type != "invalid_request_error" {
+		t.Errorf("got type %q, want %q", apiErr.Type, "invalid_request_error")
+	}
+	if apiErr.Code != "bad_param" {
+		t.Errorf("got code %q, want %q", apiErr.Code, "bad_param")
+	}
+}
+
+func TestParseAPIErrorFlatBody(t *testing.T) {
+	body := []byte(`{"error":"something went wrong"}`)
+	res := &httptest.ResponseRecorder{Code: http.StatusInternalServerError, HeaderMap: http.Header{}}
+
+	apiErr := parseAPIError(res.Result(), body)
+
+	if apiErr.Message != "something went wrong" {
+		t.Errorf("got message %q, want %q", apiErr.Message, "something went wrong")
+	}
+}
+
+func TestParseAPIErrorUnparseableBody(t *testing.T) {
+	body := []byte(`not json at all`)
+	res := &httptest.ResponseRecorder{Code: http.StatusServiceUnavailable, HeaderMap: http.Header{}}
+
+	apiErr := parseAPIError(res.Result(), body)
+
+	if apiErr.Message != res.Result().Status {
+		t.Errorf("got message %q, want fallback to response status %q", apiErr.Message, res.Result().Status)
+	}
+}
@@ -42,6 +42,7 @@ func main() {
 			},
 		},
 		SearchRecencyFilter: perplexity.RecencyFilterMonth,
+		StreamOptions:       &perplexity.StreamOptions{IncludeUsage: true},
 		Stream: func(delta perplexity.ChatCompletionResponse) {
 			// Handle incremental updates by printing the assistant's response as it streams
 			for _, choice := range delta.Choices {
@@ -50,22 +51,20 @@ func main() {
 		},
 	}
 
-	// Send the chat completion request
+	// Send the chat completion request. Even with Stream set, ChatCompletion
+	// blocks until the stream completes and returns the fully-assembled
+	// response, so there's no separate non-streaming code path to fall back to.
 	response, err := client.ChatCompletion(ctx, req)
 	if err != nil {
 		log.Fatalf("Error calling ChatCompletion: %v", err)
 	}
 
-	// If streaming is enabled, the response will be nil, and content is handled in the stream callback
-	if response == nil {
-		fmt.Println("\nStreaming completed.")
-		return
-	}
-
-	// Handle non-streaming response
+	fmt.Println("\n\nStreaming completed.")
 	if len(response.Choices) > 0 {
 		fmt.Printf("Assistant's reply:\n%s\n", response.Choices[0].Message.Content)
 	} else {
 		fmt.Println("No choices found in the response.")
 	}
+	fmt.Printf("Tokens used: %d prompt + %d completion = %d total\n",
+		response.Usage.PromptTokens, response.Usage.CompletionTokens, response.Usage.TotalTokens)
 }
@@ -0,0 +1,118 @@
+package perplexity
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is consulted by ChatCompletion before each attempt, so callers
+// running many concurrent completions can cap request rate and concurrency
+// without wrapping the client's *http.Client transport themselves.
+type Limiter interface {
+	// Wait blocks until the caller is permitted to proceed, or returns
+	// ctx.Err() if ctx is done first.
+	Wait(ctx context.Context) error
+
+	// Release signals that the request admitted by a prior successful Wait
+	// has completed, freeing any concurrency slot it held. It must be
+	// called exactly once per successful Wait, and is a no-op for
+	// limiters that don't track concurrency.
+	Release()
+}
+
+// TokenBucketLimiter is the default Limiter: it admits up to Burst requests
+// immediately, refills at RatePerSecond thereafter, and additionally caps
+// the number of requests in flight at MaxConcurrency (0 means unlimited).
+type TokenBucketLimiter struct {
+	ratePerSecond float64
+	burst         float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	sem chan struct{}
+}
+
+var _ Limiter = (*TokenBucketLimiter)(nil)
+
+// NewTokenBucketLimiter creates a TokenBucketLimiter allowing ratePerSecond
+// requests per second on average, with bursts up to burst requests. If
+// maxConcurrency is greater than 0, at most that many calls to Wait may be
+// admitted and not yet completed (via a matching call to Release) at once.
+func NewTokenBucketLimiter(ratePerSecond float64, burst int, maxConcurrency int) *TokenBucketLimiter {
+	l := &TokenBucketLimiter{
+		ratePerSecond: ratePerSecond,
+		burst:         float64(burst),
+		tokens:        float64(burst),
+		last:          time.Now(),
+	}
+
+	if maxConcurrency > 0 {
+		l.sem = make(chan struct{}, maxConcurrency)
+	}
+
+	return l
+}
+
+// Wait blocks until a token is available and, if a concurrency cap is
+// configured, a concurrency slot is free. Callers should invoke Release
+// when the request completes.
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	if l.sem != nil {
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for {
+		d := l.reserve()
+		if d <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.Release()
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees the concurrency slot acquired by Wait. It is a no-op if no
+// concurrency cap was configured.
+func (l *TokenBucketLimiter) Release() {
+	if l.sem != nil {
+		<-l.sem
+	}
+}
+
+// reserve returns how long the caller must still wait for a token to become
+// available, consuming one if it's already available (returning 0).
+func (l *TokenBucketLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.last)
+	l.last = now
+
+	l.tokens += elapsed.Seconds() * l.ratePerSecond
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	missing := 1 - l.tokens
+	return time.Duration(missing / l.ratePerSecond * float64(time.Second))
+}
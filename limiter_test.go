@@ -0,0 +1,82 @@
+package perplexity
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiterReleaseFreesSlot(t *testing.T) {
+	l := NewTokenBucketLimiter(1000, 1000, 1)
+
+	ctx := context.Background()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- l.Wait(ctx)
+	}()
+
+	select {
+	case <-blocked:
+		t.Fatal("second Wait returned before Release, concurrency cap not enforced")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.Release()
+
+	select {
+	case err := <-blocked:
+		if err != nil {
+			t.Fatalf("second Wait after Release: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second Wait never unblocked after Release")
+	}
+}
+
+func TestTokenBucketLimiterWaitReusesSlotsAcrossManyCalls(t *testing.T) {
+	l := NewTokenBucketLimiter(1000, 1000, 1)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("Wait #%d: %v", i, err)
+		}
+		l.Release()
+	}
+}
+
+func TestTokenBucketLimiterWaitCanceledContextReleasesSlot(t *testing.T) {
+	l := NewTokenBucketLimiter(1000, 1000, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	// A second caller blocked on the concurrency slot should be released by
+	// ctx cancellation without leaking the slot it never acquired.
+	waitCtx, waitCancel := context.WithCancel(context.Background())
+	defer waitCancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Wait(waitCtx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	waitCancel()
+
+	if err := <-done; err == nil {
+		t.Fatal("expected Wait to return an error after its context was canceled")
+	}
+
+	cancel()
+	l.Release()
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait after both releases should succeed immediately: %v", err)
+	}
+}
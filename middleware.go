@@ -0,0 +1,25 @@
+package perplexity
+
+import "context"
+
+// Handler performs a single chat completion. It's the type both
+// Client.ChatCompletion and every Middleware operate over, so middlewares
+// can be layered around the client without touching its *http.Client
+// transport.
+type Handler func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error)
+
+// Middleware wraps a Handler with additional behavior (logging, tracing,
+// caching, redaction, budget enforcement, ...), producing a new Handler
+// indistinguishable from calling the next one directly.
+type Middleware func(next Handler) Handler
+
+// chain composes middlewares around base, in the order given: the first
+// middleware is outermost, so it's the first to see the request and the
+// last to see the response or error.
+func chain(base Handler, middlewares []Middleware) Handler {
+	h := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
@@ -0,0 +1,236 @@
+package perplexity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Logger is the subset of *log.Logger that LoggingMiddleware needs.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// LoggingMiddleware logs the model, duration, and outcome (token usage or
+// error) of every request that passes through it.
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			if err != nil {
+				logger.Printf("perplexity: ChatCompletion model=%s duration=%s error=%v", req.Model, time.Since(start), err)
+				return nil, err
+			}
+			logger.Printf("perplexity: ChatCompletion model=%s duration=%s total_tokens=%d", req.Model, time.Since(start), resp.Usage.TotalTokens)
+			return resp, nil
+		}
+	}
+}
+
+// Span is the minimal span surface TracingMiddleware needs; a thin adapter
+// around go.opentelemetry.io/otel/trace.Span satisfies this without
+// requiring this package to import OpenTelemetry itself.
+type Span interface {
+	SetAttributes(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts a Span for each request; a thin adapter around
+// go.opentelemetry.io/otel/trace.Tracer satisfies this.
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// TracingMiddleware opens a span per request via tracer, recording the
+// model and token usage as attributes and the error, if any.
+func TracingMiddleware(tracer Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+			ctx, span := tracer.Start(ctx, "perplexity.ChatCompletion")
+			defer span.End()
+			span.SetAttributes("perplexity.model", string(req.Model))
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+
+			span.SetAttributes("perplexity.usage.prompt_tokens", resp.Usage.PromptTokens)
+			span.SetAttributes("perplexity.usage.completion_tokens", resp.Usage.CompletionTokens)
+			span.SetAttributes("perplexity.usage.total_tokens", resp.Usage.TotalTokens)
+			return resp, nil
+		}
+	}
+}
+
+// Cache is the storage backend CachingMiddleware reads and writes.
+// InMemoryCache satisfies it for single-process use; callers needing a
+// shared cache can back it with Redis or similar.
+type Cache interface {
+	Get(key string) (*ChatCompletionResponse, bool)
+	Set(key string, resp *ChatCompletionResponse)
+}
+
+// InMemoryCache is a process-local, goroutine-safe Cache.
+type InMemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*ChatCompletionResponse
+}
+
+var _ Cache = (*InMemoryCache)(nil)
+
+// NewInMemoryCache creates an empty InMemoryCache.
+func NewInMemoryCache() *InMemoryCache {
+	return &InMemoryCache{entries: make(map[string]*ChatCompletionResponse)}
+}
+
+// Get returns the cached response for key, if any.
+func (c *InMemoryCache) Get(key string) (*ChatCompletionResponse, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	resp, ok := c.entries[key]
+	return resp, ok
+}
+
+// Set stores resp under key.
+func (c *InMemoryCache) Set(key string, resp *ChatCompletionResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = resp
+}
+
+// CachingMiddleware serves repeat requests from cache, keyed by a hash of
+// the normalized request, instead of calling the provider again. Streaming
+// requests bypass the cache entirely, since a cached response can't be
+// replayed through the caller's OnUpdateHandler.
+func CachingMiddleware(cache Cache) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+			if req.Stream != nil {
+				return next(ctx, req)
+			}
+
+			key := requestCacheKey(req)
+			if resp, ok := cache.Get(key); ok {
+				return resp, nil
+			}
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+
+			cache.Set(key, resp)
+			return resp, nil
+		}
+	}
+}
+
+// requestCacheKey hashes the JSON-marshaled request, so identical requests
+// share a cache entry regardless of field ordering. Tools and ToolChoice
+// carry `json:"-"` tags (so they're excluded from ChatCompletionRequest's
+// own wire encoding) and so are folded in separately, otherwise requests
+// that differ only in which tools they offer would collide.
+func requestCacheKey(req ChatCompletionRequest) string {
+	jsonData, err := json.Marshal(&req) // uses ChatCompletionRequest.MarshalJSON
+	if err != nil {
+		// Fall back to the model name alone; worst case is a cache miss.
+		return string(req.Model)
+	}
+
+	toolsJSON, err := json.Marshal(struct {
+		Tools      []Tool `json:"tools,omitempty"`
+		ToolChoice any    `json:"tool_choice,omitempty"`
+	}{req.Tools, req.ToolChoice})
+	if err == nil {
+		jsonData = append(jsonData, toolsJSON...)
+	}
+
+	sum := sha256.Sum256(jsonData)
+	return hex.EncodeToString(sum[:])
+}
+
+// RedactionMiddleware replaces every match of any pattern in patterns with
+// replacement, across every outgoing message's content, before the request
+// reaches the provider. Useful for scrubbing secrets or PII that would
+// otherwise land in provider-side logs.
+func RedactionMiddleware(patterns []*regexp.Regexp, replacement string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+			redacted := req
+			redacted.Messages = make([]Message, len(req.Messages))
+			for i, m := range req.Messages {
+				for _, p := range patterns {
+					m.Content = p.ReplaceAllString(m.Content, replacement)
+				}
+				if len(m.ToolCalls) > 0 {
+					m.ToolCalls = redactToolCalls(m.ToolCalls, patterns, replacement)
+				}
+				redacted.Messages[i] = m
+			}
+			return next(ctx, redacted)
+		}
+	}
+}
+
+// redactToolCalls applies the same patterns to each tool call's arguments,
+// so secrets or PII echoed into a prior tool call aren't resent unredacted
+// when that call is replayed as part of the conversation history.
+func redactToolCalls(calls []ToolCall, patterns []*regexp.Regexp, replacement string) []ToolCall {
+	redacted := make([]ToolCall, len(calls))
+	for i, c := range calls {
+		for _, p := range patterns {
+			c.Function.Arguments = p.ReplaceAllString(c.Function.Arguments, replacement)
+		}
+		redacted[i] = c
+	}
+	return redacted
+}
+
+// ErrBudgetExceeded is returned by BudgetMiddleware once cumulative
+// total-token spend has reached its ceiling.
+var ErrBudgetExceeded = errors.New("perplexity: token budget exceeded")
+
+// BudgetMiddleware tracks cumulative total-token spend across every request
+// that passes through it, short-circuiting with ErrBudgetExceeded once
+// spend has already reached ceiling. The lock only guards the pre-call check
+// and the post-call spend update, not the call to next itself, so this
+// middleware doesn't serialize concurrent ChatCompletion calls (e.g. behind
+// a rate Limiter); the tradeoff is that two calls admitted in the same
+// narrow window can together push spend past ceiling before either's usage
+// is recorded.
+func BudgetMiddleware(ceiling int) Middleware {
+	var (
+		mu    sync.Mutex
+		spent int64
+	)
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+			mu.Lock()
+			exceeded := spent >= int64(ceiling)
+			mu.Unlock()
+			if exceeded {
+				return nil, ErrBudgetExceeded
+			}
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+
+			mu.Lock()
+			spent += int64(resp.Usage.TotalTokens)
+			mu.Unlock()
+			return resp, nil
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package perplexity
+
+import "testing"
+
+func TestRequestCacheKeyStableForIdenticalRequests(t *testing.T) {
+	req := ChatCompletionRequest{
+		Model:    "sonar",
+		Messages: []Message{{Role: MessageRoleUser, Content: "hi"}},
+	}
+
+	if requestCacheKey(req) != requestCacheKey(req) {
+		t.Fatal("identical requests produced different cache keys")
+	}
+}
+
+func TestRequestCacheKeyDiffersByTools(t *testing.T) {
+	base := ChatCompletionRequest{
+		Model:    "sonar",
+		Messages: []Message{{Role: MessageRoleUser, Content: "hi"}},
+	}
+
+	withTools := base
+	withTools.Tools = []Tool{{Type: "function", Function: ToolFunction{Name: "get_weather"}}}
+
+	if requestCacheKey(base) == requestCacheKey(withTools) {
+		t.Fatal("requests differing only in Tools produced the same cache key")
+	}
+}
+
+func TestRequestCacheKeyDiffersByToolChoice(t *testing.T) {
+	base := ChatCompletionRequest{
+		Model:    "sonar",
+		Messages: []Message{{Role: MessageRoleUser, Content: "hi"}},
+		Tools:    []Tool{{Type: "function", Function: ToolFunction{Name: "get_weather"}}},
+	}
+
+	forced := base
+	forced.ToolChoice = "get_weather"
+
+	if requestCacheKey(base) == requestCacheKey(forced) {
+		t.Fatal("requests differing only in ToolChoice produced the same cache key")
+	}
+}
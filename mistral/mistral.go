@@ -0,0 +1,335 @@
+// Package mistral provides a perplexity.Completer implementation backed by
+// the Mistral AI chat completions API, so that code written against the
+// perplexity package's neutral types can be pointed at Mistral instead of
+// (or in addition to) Perplexity itself.
+package mistral
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emmaly/perplexity"
+)
+
+// DefaultBaseURL is the default base URL for the Mistral AI API.
+const DefaultBaseURL = "https://api.mistral.ai/v1"
+
+// Client is a perplexity.Completer backed by the Mistral AI API.
+type Client struct {
+	token   string
+	client  *http.Client
+	baseURL string
+}
+
+var _ perplexity.Completer = (*Client)(nil)
+
+// ClientOptions represents options for configuring a new Mistral AI API client.
+type ClientOptions struct {
+	// HTTPClient is an optional *http.Client to use for requests.
+	HTTPClient *http.Client
+
+	// BaseURL is the base URL for the Mistral AI API.
+	// If empty, `DefaultBaseURL` is used.
+	BaseURL string
+}
+
+// NewClient creates a new Mistral AI API client with the given API key.
+// Optionally, you can pass a custom *http.Client to override default settings.
+// If httpClient is nil, a default client with reasonable timeouts is used.
+func NewClient(token string, options *ClientOptions) *Client {
+	var httpClient *http.Client
+	if options != nil {
+		httpClient = options.HTTPClient
+	}
+
+	if httpClient == nil {
+		transport := &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 300 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}
+
+		httpClient = &http.Client{
+			Transport: transport,
+		}
+	}
+
+	baseURL := DefaultBaseURL
+	if options != nil && options.BaseURL != "" {
+		baseURL = options.BaseURL
+	}
+
+	return &Client{
+		token:   token,
+		client:  httpClient,
+		baseURL: baseURL,
+	}
+}
+
+// wireMessage is Mistral's on-the-wire message shape.
+type wireMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// wireRequest is Mistral's on-the-wire chat completion request shape.
+type wireRequest struct {
+	Model            string        `json:"model"`
+	Messages         []wireMessage `json:"messages"`
+	MaxTokens        int           `json:"max_tokens,omitempty"`
+	Temperature      float64       `json:"temperature,omitempty"`
+	TopP             float64       `json:"top_p,omitempty"`
+	Stream           bool          `json:"stream,omitempty"`
+	PresencePenalty  float64       `json:"presence_penalty,omitempty"`
+	FrequencyPenalty float64       `json:"frequency_penalty,omitempty"`
+}
+
+type wireChoice struct {
+	Index        int         `json:"index"`
+	FinishReason string      `json:"finish_reason"`
+	Message      wireMessage `json:"message"`
+	Delta        wireMessage `json:"delta"`
+}
+
+type wireUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// wireResponse is Mistral's on-the-wire chat completion response shape.
+type wireResponse struct {
+	ID      string       `json:"id"`
+	Model   string       `json:"model"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Choices []wireChoice `json:"choices"`
+	Usage   wireUsage    `json:"usage"`
+}
+
+type wireError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+func toWireRequest(req perplexity.ChatCompletionRequest) wireRequest {
+	messages := make([]wireMessage, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = wireMessage{Role: string(m.Role), Content: m.Content}
+	}
+
+	return wireRequest{
+		Model:            string(req.Model),
+		Messages:         messages,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		Stream:           req.Stream != nil,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+	}
+}
+
+// rejectTools fails fast when req asks for tool calling: this Completer
+// doesn't translate Tools/ToolCalls into Mistral's wire format, so silently
+// dropping them would mis-translate the conversation rather than honor it.
+func rejectTools(req perplexity.ChatCompletionRequest) error {
+	if len(req.Tools) > 0 {
+		return errors.New("mistral: tool calling is not supported by this Completer")
+	}
+	for _, m := range req.Messages {
+		if m.Role == perplexity.MessageRoleTool || len(m.ToolCalls) > 0 {
+			return errors.New("mistral: tool-call messages are not supported by this Completer")
+		}
+	}
+	return nil
+}
+
+func fromWireResponse(resp wireResponse) *perplexity.ChatCompletionResponse {
+	choices := make([]perplexity.Choice, len(resp.Choices))
+	for i, c := range resp.Choices {
+		choices[i] = perplexity.Choice{
+			Index:        c.Index,
+			FinishReason: perplexity.FinishReason(c.FinishReason),
+			Message:      perplexity.Message{Role: perplexity.MessageRole(c.Message.Role), Content: c.Message.Content},
+			Delta:        perplexity.Message{Role: perplexity.MessageRole(c.Delta.Role), Content: c.Delta.Content},
+		}
+	}
+
+	return &perplexity.ChatCompletionResponse{
+		ID:      resp.ID,
+		Model:   resp.Model,
+		Object:  resp.Object,
+		Created: resp.Created,
+		Choices: choices,
+		Usage: perplexity.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}
+}
+
+// ChatCompletion sends a chat completion request to the Mistral AI API,
+// translating between perplexity's neutral types and Mistral's wire format.
+func (c *Client) ChatCompletion(ctx context.Context, req perplexity.ChatCompletionRequest) (*perplexity.ChatCompletionResponse, error) {
+	url := c.baseURL + "/chat/completions"
+
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	if len(req.Messages) == 0 {
+		return nil, errors.New("at least one message is required")
+	}
+	if req.PresencePenalty != 0.0 && req.FrequencyPenalty != 0.0 {
+		return nil, errors.New("PresencePenalty and FrequencyPenalty are incompatible; only one should be set")
+	}
+	if err := rejectTools(req); err != nil {
+		return nil, err
+	}
+
+	jsonData, err := json.Marshal(toWireRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		var e wireError
+		if err := json.Unmarshal(bodyBytes, &e); err == nil && e.Message != "" {
+			return nil, fmt.Errorf("API error: %s", e.Message)
+		}
+		return nil, fmt.Errorf("unexpected status code: %s", res.Status)
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "text/event-stream" || strings.HasPrefix(contentType, "text/event-stream;") {
+		if req.Stream == nil {
+			return nil, errors.New("streaming response received but no stream handler provided")
+		}
+		return c.handleStreamingResponse(res, req.Stream)
+	}
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var response wireResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, err
+	}
+
+	return fromWireResponse(response), nil
+}
+
+// handleStreamingResponse handles streaming responses from the Mistral AI
+// API. It reads the Server-Sent Events (SSE) from the response, calling
+// onUpdate with each event translated into perplexity's neutral
+// ChatCompletionResponse shape, while itself accumulating the deltas into a
+// single fully-assembled ChatCompletionResponse, which it returns once the
+// stream completes so the caller doesn't have to concatenate deltas itself.
+func (c *Client) handleStreamingResponse(res *http.Response, onUpdate perplexity.OnUpdateHandler) (*perplexity.ChatCompletionResponse, error) {
+	defer res.Body.Close()
+
+	scanner := bufio.NewScanner(res.Body)
+
+	var final perplexity.ChatCompletionResponse
+	var content strings.Builder
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			continue
+		}
+
+		if line == "data: [DONE]" {
+			break
+		}
+
+		if len(line) >= 6 && line[:6] == "data: " {
+			jsonData := line[6:]
+
+			var response wireResponse
+			if err := json.Unmarshal([]byte(jsonData), &response); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal streaming event: %w", err)
+			}
+
+			delta := fromWireResponse(response)
+			accumulate(&final, &content, delta)
+
+			onUpdate(*delta)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading streaming response: %w", err)
+	}
+
+	if len(final.Choices) > 0 {
+		final.Choices[0].Message.Content = content.String()
+	}
+
+	return &final, nil
+}
+
+// accumulate folds a single streamed delta into the in-progress final
+// response: growing message content, and adopting whichever fields (ID,
+// Model, Usage) that chunk carries.
+func accumulate(final *perplexity.ChatCompletionResponse, content *strings.Builder, delta *perplexity.ChatCompletionResponse) {
+	if delta.ID != "" {
+		final.ID = delta.ID
+	}
+	if delta.Model != "" {
+		final.Model = delta.Model
+	}
+	if delta.Created != 0 {
+		final.Created = delta.Created
+	}
+	if delta.Usage != (perplexity.Usage{}) {
+		final.Usage = delta.Usage
+	}
+
+	for _, choice := range delta.Choices {
+		content.WriteString(choice.Delta.Content)
+		if choice.FinishReason != "" {
+			if len(final.Choices) == 0 {
+				final.Choices = []perplexity.Choice{{}}
+			}
+			final.Choices[0].FinishReason = choice.FinishReason
+			final.Choices[0].Message.Role = perplexity.MessageRoleAssistant
+		}
+	}
+
+	if len(final.Choices) == 0 && len(delta.Choices) > 0 {
+		final.Choices = []perplexity.Choice{{Message: perplexity.Message{Role: perplexity.MessageRoleAssistant}}}
+	}
+}
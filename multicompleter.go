@@ -0,0 +1,72 @@
+package perplexity
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// MultiCompleterStrategy selects how MultiCompleter distributes requests
+// across its underlying Completers.
+type MultiCompleterStrategy int
+
+const (
+	// MultiCompleterFallback tries each Completer in order, returning the
+	// first successful response. A Completer is only tried if the previous
+	// one returned an error.
+	MultiCompleterFallback MultiCompleterStrategy = iota
+
+	// MultiCompleterRoundRobin distributes requests evenly across the
+	// Completers in order, wrapping back to the first after the last. It
+	// does not retry against other Completers on failure.
+	MultiCompleterRoundRobin
+)
+
+// MultiCompleter is a Completer that fans a ChatCompletionRequest out to one
+// of several underlying Completers, according to its MultiCompleterStrategy.
+// This is useful for falling back to a secondary provider when the primary
+// is unavailable, or for spreading load across multiple providers or API
+// keys.
+type MultiCompleter struct {
+	completers []Completer
+	strategy   MultiCompleterStrategy
+
+	next uint64
+}
+
+var _ Completer = (*MultiCompleter)(nil)
+
+// NewMultiCompleter creates a MultiCompleter that distributes requests
+// across completers according to strategy. At least one Completer is
+// required.
+func NewMultiCompleter(strategy MultiCompleterStrategy, completers ...Completer) (*MultiCompleter, error) {
+	if len(completers) == 0 {
+		return nil, errors.New("at least one Completer is required")
+	}
+
+	return &MultiCompleter{
+		completers: completers,
+		strategy:   strategy,
+	}, nil
+}
+
+// ChatCompletion sends req to one (or, in the fallback case, potentially
+// several) of the underlying Completers, according to the configured
+// MultiCompleterStrategy.
+func (m *MultiCompleter) ChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	switch m.strategy {
+	case MultiCompleterRoundRobin:
+		completer := m.completers[atomic.AddUint64(&m.next, 1)%uint64(len(m.completers))]
+		return completer.ChatCompletion(ctx, req)
+	default:
+		var lastErr error
+		for _, completer := range m.completers {
+			resp, err := completer.ChatCompletion(ctx, req)
+			if err == nil {
+				return resp, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
@@ -0,0 +1,131 @@
+package perplexity
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeCompleter struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (f *fakeCompleter) ChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ChatCompletionResponse{Model: f.name}, nil
+}
+
+func TestNewMultiCompleterRequiresACompleter(t *testing.T) {
+	if _, err := NewMultiCompleter(MultiCompleterFallback); err == nil {
+		t.Fatal("expected an error when no Completers are given")
+	}
+}
+
+func TestMultiCompleterFallbackSkipsFailingCompleters(t *testing.T) {
+	failing := &fakeCompleter{name: "failing", err: errors.New("unavailable")}
+	working := &fakeCompleter{name: "working"}
+
+	mc, err := NewMultiCompleter(MultiCompleterFallback, failing, working)
+	if err != nil {
+		t.Fatalf("NewMultiCompleter: %v", err)
+	}
+
+	resp, err := mc.ChatCompletion(context.Background(), ChatCompletionRequest{})
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if resp.Model != "working" {
+		t.Errorf("got model %q, want %q", resp.Model, "working")
+	}
+	if failing.calls != 1 || working.calls != 1 {
+		t.Errorf("got calls failing=%d working=%d, want 1 each", failing.calls, working.calls)
+	}
+}
+
+func TestMultiCompleterFallbackReturnsLastErrorWhenAllFail(t *testing.T) {
+	first := &fakeCompleter{name: "first", err: errors.New("first failed")}
+	second := &fakeCompleter{name: "second", err: errors.New("second failed")}
+
+	mc, err := NewMultiCompleter(MultiCompleterFallback, first, second)
+	if err != nil {
+		t.Fatalf("NewMultiCompleter: %v", err)
+	}
+
+	_, err = mc.ChatCompletion(context.Background(), ChatCompletionRequest{})
+	if err == nil || err.Error() != "second failed" {
+		t.Errorf("got error %v, want the last Completer's error", err)
+	}
+}
+
+func TestMultiCompleterFallbackDoesNotTryLaterCompletersOnSuccess(t *testing.T) {
+	first := &fakeCompleter{name: "first"}
+	second := &fakeCompleter{name: "second"}
+
+	mc, err := NewMultiCompleter(MultiCompleterFallback, first, second)
+	if err != nil {
+		t.Fatalf("NewMultiCompleter: %v", err)
+	}
+
+	if _, err := mc.ChatCompletion(context.Background(), ChatCompletionRequest{}); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if first.calls != 1 || second.calls != 0 {
+		t.Errorf("got calls first=%d second=%d, want first=1 second=0", first.calls, second.calls)
+	}
+}
+
+func TestMultiCompleterRoundRobinDistributesAndWraps(t *testing.T) {
+	a := &fakeCompleter{name: "a"}
+	b := &fakeCompleter{name: "b"}
+	c := &fakeCompleter{name: "c"}
+
+	mc, err := NewMultiCompleter(MultiCompleterRoundRobin, a, b, c)
+	if err != nil {
+		t.Fatalf("NewMultiCompleter: %v", err)
+	}
+
+	var seen []string
+	for i := 0; i < 6; i++ {
+		resp, err := mc.ChatCompletion(context.Background(), ChatCompletionRequest{})
+		if err != nil {
+			t.Fatalf("ChatCompletion: %v", err)
+		}
+		seen = append(seen, resp.Model)
+	}
+
+	if a.calls != 2 || b.calls != 2 || c.calls != 2 {
+		t.Errorf("got calls a=%d b=%d c=%d, want 2 each across 6 requests", a.calls, b.calls, c.calls)
+	}
+
+	if seen[0] == seen[1] && seen[1] == seen[2] {
+		t.Errorf("expected round-robin to spread requests across completers, got all %q", seen[0])
+	}
+}
+
+func TestMultiCompleterRoundRobinDoesNotRetryOnFailure(t *testing.T) {
+	failing := &fakeCompleter{name: "failing", err: errors.New("down")}
+	working := &fakeCompleter{name: "working"}
+
+	mc, err := NewMultiCompleter(MultiCompleterRoundRobin, failing, working)
+	if err != nil {
+		t.Fatalf("NewMultiCompleter: %v", err)
+	}
+
+	// next starts at 0 and is pre-incremented, so the first call lands on
+	// index 1 (working); call again to land on index 0 (failing) and
+	// confirm the error propagates without falling back to working.
+	if _, err := mc.ChatCompletion(context.Background(), ChatCompletionRequest{}); err != nil {
+		t.Fatalf("first ChatCompletion: %v", err)
+	}
+	if _, err := mc.ChatCompletion(context.Background(), ChatCompletionRequest{}); err == nil {
+		t.Fatal("expected the second call to hit the failing completer and return its error")
+	}
+	if working.calls != 1 || failing.calls != 1 {
+		t.Errorf("got calls failing=%d working=%d, want 1 each (no fallback)", failing.calls, working.calls)
+	}
+}
@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emmaly/perplexity"
+)
+
+func TestChatCompletionTranslatesToolCalls(t *testing.T) {
+	var gotBody wireRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(wireResponse{
+			ID:    "resp1",
+			Model: "gpt-4o",
+			Choices: []wireChoice{
+				{
+					Index:        0,
+					FinishReason: "tool_calls",
+					Message: wireMessage{
+						Role: "assistant",
+						ToolCalls: []wireToolCall{
+							{
+								ID:   "call_1",
+								Type: "function",
+								Function: wireToolCallFunction{
+									Name:      "get_weather",
+									Arguments: `{"city":"Paris"}`,
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", &ClientOptions{BaseURL: server.URL})
+
+	req := perplexity.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []perplexity.Message{
+			{Role: perplexity.MessageRoleUser, Content: "What's the weather in Paris?"},
+		},
+		Tools: []perplexity.Tool{
+			{
+				Type: "function",
+				Function: perplexity.ToolFunction{
+					Name:        "get_weather",
+					Description: "Get the weather for a city",
+					Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+				},
+			},
+		},
+	}
+
+	resp, err := client.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if len(gotBody.Tools) != 1 || gotBody.Tools[0].Function.Name != "get_weather" {
+		t.Fatalf("request did not carry the tool definition through to the wire: %+v", gotBody.Tools)
+	}
+
+	if len(resp.Choices) != 1 {
+		t.Fatalf("got %d choices, want 1", len(resp.Choices))
+	}
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 1 {
+		t.Fatalf("got %d tool calls, want 1", len(calls))
+	}
+	if calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("got tool call %+v, want get_weather with Paris arguments", calls[0])
+	}
+	if resp.Choices[0].FinishReason != perplexity.FinishReason("tool_calls") {
+		t.Errorf("got finish reason %q, want %q", resp.Choices[0].FinishReason, "tool_calls")
+	}
+}
+
+func TestChatCompletionTranslatesToolResultMessage(t *testing.T) {
+	var gotBody wireRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(wireResponse{
+			ID:    "resp2",
+			Model: "gpt-4o",
+			Choices: []wireChoice{
+				{Index: 0, FinishReason: "stop", Message: wireMessage{Role: "assistant", Content: "It's sunny in Paris."}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", &ClientOptions{BaseURL: server.URL})
+
+	req := perplexity.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []perplexity.Message{
+			{Role: perplexity.MessageRoleUser, Content: "What's the weather in Paris?"},
+			{
+				Role: perplexity.MessageRoleAssistant,
+				ToolCalls: []perplexity.ToolCall{
+					{ID: "call_1", Type: "function", Function: perplexity.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				},
+			},
+			{Role: perplexity.MessageRoleTool, ToolCallID: "call_1", Content: `{"temp_c":22,"condition":"sunny"}`},
+		},
+	}
+
+	if _, err := client.ChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if len(gotBody.Messages) != 3 {
+		t.Fatalf("got %d wire messages, want 3", len(gotBody.Messages))
+	}
+	toolMsg := gotBody.Messages[2]
+	if toolMsg.Role != "tool" || toolMsg.ToolCallID != "call_1" {
+		t.Errorf("got tool result message %+v, want role=tool tool_call_id=call_1", toolMsg)
+	}
+	assistantMsg := gotBody.Messages[1]
+	if len(assistantMsg.ToolCalls) != 1 || assistantMsg.ToolCalls[0].ID != "call_1" {
+		t.Errorf("got assistant message %+v, want a single tool call with ID call_1", assistantMsg)
+	}
+}
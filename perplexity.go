@@ -16,9 +16,12 @@ import (
 
 // Client is a client for the Perplexity AI API.
 type Client struct {
-	token   string
-	client  *http.Client
-	baseURL string
+	token       string
+	client      *http.Client
+	baseURL     string
+	retryPolicy RetryPolicy
+	limiter     Limiter
+	handler     Handler
 }
 
 // DefaultBaseURL is the default base URL for the Perplexity API.
@@ -32,6 +35,20 @@ type ClientOptions struct {
 	// BaseURL is the base URL for the Perplexity API.
 	// If empty, `DefaultBaseURL` is used.
 	BaseURL string
+
+	// RetryPolicy configures automatic retry of transient failures.
+	// If nil, `DefaultRetryPolicy()` is used.
+	RetryPolicy *RetryPolicy
+
+	// Limiter, if set, is consulted before every attempt so callers running
+	// many concurrent completions can cap request rate and concurrency.
+	// If nil, requests are not rate limited by the client.
+	Limiter Limiter
+
+	// Middlewares wraps every call to ChatCompletion, in the order given:
+	// the first middleware is outermost, observing the request before
+	// anything else (including retries) and the response/error last.
+	Middlewares []Middleware
 }
 
 // MessageRole represents the role of the speaker in a message.
@@ -44,16 +61,29 @@ const (
 	MessageRoleUser MessageRole = "user"
 	// MessageRoleAssistant represents an assistant message.
 	MessageRoleAssistant MessageRole = "assistant"
+	// MessageRoleTool represents the result of a tool call, round-tripped
+	// back into the conversation so the model can see what the tool
+	// returned. ToolCallID must match the ID of the ToolCall it answers.
+	MessageRoleTool MessageRole = "tool"
 )
 
 // Message represents a message in the conversation.
 type Message struct {
 	// Role of the speaker in this turn of conversation.
-	// Allowed values are `MessageRoleSystem`, `MessageRoleUser`, or `MessageRoleAssistant`.
+	// Allowed values are `MessageRoleSystem`, `MessageRoleUser`, `MessageRoleAssistant`,
+	// or `MessageRoleTool`.
 	Role MessageRole `json:"role"`
 
 	// Content is the contents of the message in this turn of conversation.
 	Content string `json:"content"`
+
+	// ToolCalls is the list of tool calls the model made in this turn.
+	// Only populated on assistant messages when the request included Tools.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID identifies which ToolCall this message answers.
+	// Required when Role is `MessageRoleTool`.
+	ToolCallID string `json:"tool_call_id,omitempty"`
 }
 
 // RecencyFilter represents a recency filter for search results.
@@ -176,6 +206,30 @@ type ChatCompletionRequest struct {
 	// Incompatible with PresencePenalty.
 	// Default: `1.0`
 	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
+
+	// Tools is a list of tools the model may call. The Perplexity API has no
+	// native notion of tool calls; when Tools is non-empty, ChatCompletion
+	// enforces them client-side by injecting their schemas into the system
+	// prompt and parsing the resulting Message for a matching tool call.
+	Tools []Tool `json:"-"`
+
+	// ToolChoice controls whether/which tool the model must call: "auto"
+	// (default, the model decides), "none", or a specific tool name.
+	ToolChoice any `json:"-"`
+
+	// StreamOptions configures additional behavior for streamed responses.
+	// Only meaningful when Stream is set.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions configures additional behavior for streamed chat completions.
+type StreamOptions struct {
+	// IncludeUsage, if true, asks the API to emit one additional, final SSE
+	// chunk carrying the full Usage for the streamed request. handleStreamingResponse
+	// surfaces this chunk through OnUpdateHandler like any other delta; it
+	// also appears on the fully-assembled response ChatCompletion returns
+	// once the stream completes.
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 // OnUpdateHandler is a callback function that is called when new tokens are generated.
@@ -200,6 +254,21 @@ type ChatCompletionResponse struct {
 
 	// Usage contains usage statistics for the completion request.
 	Usage Usage `json:"usage"`
+
+	// Citations is the list of source URLs used to generate the response.
+	// Only present when the request set ReturnCitations.
+	// *This feature is only available via closed beta access.*
+	Citations []string `json:"citations,omitempty"`
+
+	// Images is the list of images related to the response.
+	// Only present when the request set ReturnImages.
+	// *This feature is only available via closed beta access.*
+	Images []Image `json:"images,omitempty"`
+
+	// RelatedQuestions is a list of questions related to the query.
+	// Only present when the request set ReturnRelatedQuestions.
+	// *This feature is only available via closed beta access.*
+	RelatedQuestions []string `json:"related_questions,omitempty"`
 }
 
 // Choice represents a single completion choice generated by the model.
@@ -220,6 +289,21 @@ type Choice struct {
 	Delta Message `json:"delta"`
 }
 
+// Image represents a single image returned alongside a chat completion.
+type Image struct {
+	// ImageURL is the URL of the image itself.
+	ImageURL string `json:"image_url"`
+
+	// OriginURL is the URL of the page the image was found on.
+	OriginURL string `json:"origin_url"`
+
+	// Height is the image's height in pixels.
+	Height int `json:"height"`
+
+	// Width is the image's width in pixels.
+	Width int `json:"width"`
+}
+
 // Usage contains usage statistics for the completion request.
 type Usage struct {
 	// PromptTokens is the number of tokens provided in the request prompt.
@@ -277,11 +361,27 @@ func NewClient(token string, options *ClientOptions) *Client {
 		baseURL = options.BaseURL
 	}
 
-	return &Client{
-		token:   token,
-		client:  httpClient,
-		baseURL: baseURL,
+	retryPolicy := DefaultRetryPolicy()
+	var limiter Limiter
+	var middlewares []Middleware
+	if options != nil {
+		if options.RetryPolicy != nil {
+			retryPolicy = *options.RetryPolicy
+		}
+		limiter = options.Limiter
+		middlewares = options.Middlewares
+	}
+
+	c := &Client{
+		token:       token,
+		client:      httpClient,
+		baseURL:     baseURL,
+		retryPolicy: retryPolicy,
+		limiter:     limiter,
 	}
+	c.handler = chain(c.doChatCompletion, middlewares)
+
+	return c
 }
 
 // MarshalJSON marshals a ChatCompletionRequest into JSON.
@@ -296,26 +396,132 @@ func (req *ChatCompletionRequest) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// ChatCompletion sends a chat completion request to the Perplexity AI API.
+// ChatCompletion sends a chat completion request to the Perplexity AI API,
+// passing it through any ClientOptions.Middlewares before retries, rate
+// limiting, and the HTTP call itself (see doChatCompletion).
 func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	url := c.baseURL + "/chat/completions"
+	return c.handler(ctx, req)
+}
+
+// doChatCompletion is the innermost Handler: it validates req, then
+// automatically retries transient failures according to c.retryPolicy
+// (DefaultRetryPolicy unless overridden via ClientOptions.RetryPolicy), and
+// consults c.limiter, if configured, before every attempt. A 429 or 5xx
+// response honors the server's Retry-After header when present; network
+// errors are retried, permanent 4xx errors are not. Once streaming has
+// delivered at least one delta to req.Stream, the request is no longer
+// retried, since the caller has already seen partial output.
+func (c *Client) doChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	if err := validateChatCompletionRequest(req); err != nil {
+		return nil, err
+	}
+
+	var streamed bool
+	if req.Stream != nil {
+		onUpdate := req.Stream
+		req.Stream = func(delta ChatCompletionResponse) {
+			streamed = true
+			onUpdate(delta)
+		}
+	}
+
+	maxAttempts := c.retryPolicy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		resp, err := func() (*ChatCompletionResponse, error) {
+			if c.limiter != nil {
+				if err := c.limiter.Wait(ctx); err != nil {
+					return nil, err
+				}
+				defer c.limiter.Release()
+			}
+			return c.chatCompletionOnce(ctx, req)
+		}()
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+
+		if attempt == maxAttempts || streamed {
+			break
+		}
 
-	// Validate the request
+		retryAfter := time.Duration(0)
+		var statusErr *httpStatusError
+		switch {
+		case errors.As(err, &statusErr):
+			if !c.retryPolicy.isRetryableStatus(statusErr.StatusCode) {
+				return nil, lastErr
+			}
+			retryAfter = statusErr.RetryAfter
+		case isRetryableNetworkError(err):
+			// fall through to backoff below
+		default:
+			return nil, lastErr
+		}
+
+		timer := time.NewTimer(c.retryPolicy.delay(attempt, retryAfter))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	return nil, lastErr
+}
+
+// validateChatCompletionRequest performs the validation that should fail a
+// request immediately, without consuming a retry attempt.
+func validateChatCompletionRequest(req ChatCompletionRequest) error {
 	if req.Model == "" {
-		return nil, errors.New("model is required")
+		return errors.New("model is required")
 	}
 	if len(req.Messages) == 0 {
-		return nil, errors.New("at least one message is required")
+		return errors.New("at least one message is required")
 	}
-	if req.Messages[len(req.Messages)-1].Role != MessageRoleUser {
-		return nil, errors.New("the last message must be from the user")
+	lastRole := req.Messages[len(req.Messages)-1].Role
+	if lastRole != MessageRoleUser && lastRole != MessageRoleTool {
+		return errors.New("the last message must be from the user or a tool")
 	}
 	if req.PresencePenalty != 0.0 && req.FrequencyPenalty != 0.0 {
-		return nil, errors.New("PresencePenalty and FrequencyPenalty are incompatible; only one should be set")
+		return errors.New("PresencePenalty and FrequencyPenalty are incompatible; only one should be set")
+	}
+	return nil
+}
+
+// isRetryableNetworkError reports whether err is a network-level failure
+// (timeout, connection refused, etc.) worth retrying, as opposed to e.g. a
+// request-marshaling error.
+func isRetryableNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// chatCompletionOnce makes a single attempt at a chat completion request,
+// with no retry logic of its own.
+func (c *Client) chatCompletionOnce(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	url := c.baseURL + "/chat/completions"
+
+	// The Perplexity API has no native concept of tool calls: when Tools is
+	// set and ToolChoice doesn't disable them, enforce them client-side by
+	// folding their schemas into the system prompt and translating any
+	// tool-result messages into user-role messages it understands.
+	enforceTools, forcedTool := resolveToolChoice(req.ToolChoice, req.Tools)
+	enforceTools = enforceTools && len(req.Tools) > 0
+
+	sendReq := req
+	if enforceTools {
+		sendReq.Messages = withToolSchemaPrompt(translateToolMessages(req.Messages), req.Tools, forcedTool)
 	}
 
 	// Marshal the payload to JSON
-	jsonData, err := json.Marshal(&req) // if this is not a pointer, it will not use the custom MarshalJSON
+	jsonData, err := json.Marshal(&sendReq) // if this is not a pointer, it will not use the custom MarshalJSON
 	if err != nil {
 		return nil, err
 	}
@@ -340,11 +546,11 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 	// Check for HTTP errors
 	if res.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(res.Body)
-		var e apiError
-		if err := json.Unmarshal(bodyBytes, &e); err == nil && e.Error != "" {
-			return nil, fmt.Errorf("API error: %s", e.Error)
+		return nil, &httpStatusError{
+			StatusCode: res.StatusCode,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+			err:        parseAPIError(res, bodyBytes),
 		}
-		return nil, fmt.Errorf("unexpected status code: %s", res.Status)
 	}
 
 	// Check if the response is a Server-Sent Events stream
@@ -353,7 +559,17 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 		if req.Stream == nil {
 			return nil, errors.New("streaming response received but no stream handler provided")
 		}
-		return nil, c.handleStreamingResponse(res, req.Stream)
+		response, err := c.handleStreamingResponse(res, req.Stream)
+		if err != nil {
+			return nil, err
+		}
+		// onUpdate has already seen each delta as raw content; only the
+		// returned, fully-assembled response can have its tool call
+		// extracted, since extraction needs the whole message.
+		if enforceTools {
+			applyToolCallExtraction(response, req.Tools)
+		}
+		return response, nil
 	}
 
 	// Read and unmarshal the response body
@@ -367,22 +583,33 @@ func (c *Client) ChatCompletion(ctx context.Context, req ChatCompletionRequest)
 		return nil, err
 	}
 
+	if enforceTools {
+		applyToolCallExtraction(&response, req.Tools)
+	}
+
 	return &response, nil
 }
 
 // handleStreamingResponse handles streaming responses from the Perplexity AI API.
-// It reads the Server-Sent Events (SSE) from the response and constructs the final ChatCompletionResponse.
-func (c *Client) handleStreamingResponse(res *http.Response, onUpdate func(delta ChatCompletionResponse)) error {
+// It reads the Server-Sent Events (SSE) from the response, calling onUpdate with
+// each incremental delta (including the terminal usage-only frame emitted when
+// StreamOptions.IncludeUsage is set), while itself accumulating the deltas into
+// a single fully-assembled ChatCompletionResponse, which it returns once the
+// stream completes so the caller doesn't have to concatenate deltas itself.
+func (c *Client) handleStreamingResponse(res *http.Response, onUpdate OnUpdateHandler) (*ChatCompletionResponse, error) {
 	defer res.Body.Close()
 
 	// onUpdate must be set
 	if onUpdate == nil {
-		return errors.New("onUpdate handler is required for streaming responses")
+		return nil, errors.New("onUpdate handler is required for streaming responses")
 	}
 
 	// Create a scanner to read the response line by line
 	scanner := bufio.NewScanner(res.Body)
 
+	var final ChatCompletionResponse
+	var content strings.Builder
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -405,17 +632,65 @@ func (c *Client) handleStreamingResponse(res *http.Response, onUpdate func(delta
 			var response ChatCompletionResponse
 			err := json.Unmarshal([]byte(jsonData), &response)
 			if err != nil {
-				return fmt.Errorf("failed to unmarshal streaming event: %w", err)
+				return nil, fmt.Errorf("failed to unmarshal streaming event: %w", err)
 			}
 
+			accumulate(&final, &content, response)
+
 			// Call the onUpdate handler
 			onUpdate(response)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading streaming response: %w", err)
+		return nil, fmt.Errorf("error reading streaming response: %w", err)
 	}
 
-	return nil
+	if len(final.Choices) > 0 {
+		final.Choices[0].Message.Content = content.String()
+	}
+
+	return &final, nil
+}
+
+// accumulate folds a single streamed delta into the in-progress final
+// response: growing message content, and adopting whichever fields (ID,
+// Model, Usage, Citations, Images, RelatedQuestions) that chunk carries.
+func accumulate(final *ChatCompletionResponse, content *strings.Builder, delta ChatCompletionResponse) {
+	if delta.ID != "" {
+		final.ID = delta.ID
+	}
+	if delta.Model != "" {
+		final.Model = delta.Model
+	}
+	if delta.Created != 0 {
+		final.Created = delta.Created
+	}
+	if delta.Usage != (Usage{}) {
+		final.Usage = delta.Usage
+	}
+	if len(delta.Citations) > 0 {
+		final.Citations = delta.Citations
+	}
+	if len(delta.Images) > 0 {
+		final.Images = delta.Images
+	}
+	if len(delta.RelatedQuestions) > 0 {
+		final.RelatedQuestions = delta.RelatedQuestions
+	}
+
+	for _, choice := range delta.Choices {
+		content.WriteString(choice.Delta.Content)
+		if choice.FinishReason != "" {
+			if len(final.Choices) == 0 {
+				final.Choices = []Choice{{}}
+			}
+			final.Choices[0].FinishReason = choice.FinishReason
+			final.Choices[0].Message.Role = MessageRoleAssistant
+		}
+	}
+
+	if len(final.Choices) == 0 && len(delta.Choices) > 0 {
+		final.Choices = []Choice{{Message: Message{Role: MessageRoleAssistant}}}
+	}
 }
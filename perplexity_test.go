@@ -0,0 +1,114 @@
+package perplexity
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testRequest() ChatCompletionRequest {
+	return ChatCompletionRequest{
+		Model:    "sonar",
+		Messages: []Message{{Role: MessageRoleUser, Content: "hi"}},
+	}
+}
+
+func TestChatCompletionRetriesRetryableStatus(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"unavailable"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"resp1","model":"sonar","choices":[{"index":0,"message":{"role":"assistant","content":"hello"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", &ClientOptions{
+		BaseURL: server.URL,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    10 * time.Millisecond,
+		},
+	})
+
+	resp, err := client.ChatCompletion(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+	if resp.Choices[0].Message.Content != "hello" {
+		t.Errorf("got content %q, want %q", resp.Choices[0].Message.Content, "hello")
+	}
+}
+
+func TestChatCompletionDoesNotRetryNonRetryableStatus(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"bad request","code":"invalid_model"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", &ClientOptions{
+		BaseURL: server.URL,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    10 * time.Millisecond,
+		},
+	})
+
+	_, err := client.ChatCompletion(context.Background(), testRequest())
+	if err == nil {
+		t.Fatal("expected an error for a 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1 (non-retryable status shouldn't be retried)", attempts)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected err to wrap *APIError, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", apiErr.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestChatCompletionExhaustsRetriesAndReturnsLastError(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"message":"still broken"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", &ClientOptions{
+		BaseURL: server.URL,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    10 * time.Millisecond,
+		},
+	})
+
+	_, err := client.ChatCompletion(context.Background(), testRequest())
+	if err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3 (MaxAttempts)", attempts)
+	}
+}
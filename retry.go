@@ -0,0 +1,120 @@
+package perplexity
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how ChatCompletion retries transient failures.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts (including the first),
+	// made against the API before giving up. A value of 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. Subsequent retries
+	// back off exponentially from this value.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the computed backoff delay, regardless of attempt count.
+	MaxDelay time.Duration
+
+	// JitterFraction randomizes each computed delay by up to this fraction
+	// (e.g. 0.2 means +/-20%), to avoid synchronized retries across callers.
+	JitterFraction float64
+
+	// RetryableStatusCodes lists the HTTP status codes that are safe to
+	// retry. If nil, DefaultRetryableStatusCodes is used.
+	RetryableStatusCodes map[int]bool
+}
+
+// DefaultRetryableStatusCodes are the status codes retried when a
+// RetryPolicy doesn't specify its own set: 429 (rate limited) and the
+// 5xx codes that typically indicate a transient server-side problem.
+var DefaultRetryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// DefaultRetryPolicy returns the RetryPolicy used when ClientOptions.RetryPolicy is nil.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		BaseDelay:      500 * time.Millisecond,
+		MaxDelay:       30 * time.Second,
+		JitterFraction: 0.2,
+	}
+}
+
+func (p RetryPolicy) isRetryableStatus(statusCode int) bool {
+	codes := p.RetryableStatusCodes
+	if codes == nil {
+		codes = DefaultRetryableStatusCodes
+	}
+	return codes[statusCode]
+}
+
+// delay computes the backoff before the given retry attempt (1 = first
+// retry, i.e. the delay before the second overall attempt), honoring a
+// server-provided Retry-After duration when present.
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return p.capped(retryAfter)
+	}
+
+	backoff := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	d := p.capped(time.Duration(backoff))
+
+	if p.JitterFraction > 0 {
+		jitter := (rand.Float64()*2 - 1) * p.JitterFraction * float64(d)
+		d += time.Duration(jitter)
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+func (p RetryPolicy) capped(d time.Duration) time.Duration {
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// httpStatusError wraps a non-200 HTTP response so the retry loop in
+// doChatCompletion can inspect its status code and any Retry-After duration
+// without parsing the error message.
+type httpStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *httpStatusError) Error() string { return e.err.Error() }
+func (e *httpStatusError) Unwrap() error { return e.err }
+
+// parseRetryAfter parses an HTTP Retry-After header, which may be either a
+// number of seconds or an HTTP date. It returns 0 if the header is absent
+// or unparseable.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
@@ -0,0 +1,84 @@
+package perplexity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelayExponentialBackoff(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  10 * time.Second,
+	}
+
+	got := p.delay(1, 0)
+	if want := 100 * time.Millisecond; got != want {
+		t.Errorf("attempt 1: got %v, want %v", got, want)
+	}
+
+	got = p.delay(2, 0)
+	if want := 200 * time.Millisecond; got != want {
+		t.Errorf("attempt 2: got %v, want %v", got, want)
+	}
+
+	got = p.delay(3, 0)
+	if want := 400 * time.Millisecond; got != want {
+		t.Errorf("attempt 3: got %v, want %v", got, want)
+	}
+}
+
+func TestRetryPolicyDelayCapsAtMaxDelay(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  2 * time.Second,
+	}
+
+	got := p.delay(10, 0)
+	if got != 2*time.Second {
+		t.Errorf("got %v, want capped %v", got, 2*time.Second)
+	}
+}
+
+func TestRetryPolicyDelayHonorsRetryAfter(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  30 * time.Second,
+	}
+
+	got := p.delay(1, 5*time.Second)
+	if got != 5*time.Second {
+		t.Errorf("got %v, want Retry-After value %v", got, 5*time.Second)
+	}
+}
+
+func TestRetryPolicyDelayJitterStaysWithinFraction(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay:      time.Second,
+		MaxDelay:       30 * time.Second,
+		JitterFraction: 0.2,
+	}
+
+	base := time.Second
+	for i := 0; i < 50; i++ {
+		d := p.delay(1, 0)
+		lo := time.Duration(float64(base) * 0.8)
+		hi := time.Duration(float64(base) * 1.2)
+		if d < lo || d > hi {
+			t.Fatalf("delay %v out of jitter range [%v, %v]", d, lo, hi)
+		}
+	}
+}
+
+func TestRetryPolicyDelayNeverNegative(t *testing.T) {
+	p := RetryPolicy{
+		BaseDelay:      time.Millisecond,
+		MaxDelay:       time.Second,
+		JitterFraction: 1.0,
+	}
+
+	for i := 0; i < 50; i++ {
+		if d := p.delay(1, 0); d < 0 {
+			t.Fatalf("delay returned negative duration: %v", d)
+		}
+	}
+}
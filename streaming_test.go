@@ -0,0 +1,68 @@
+package perplexity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChatCompletionStreamingAssemblesFullResponse(t *testing.T) {
+	chunks := []string{
+		`{"id":"resp1","model":"sonar","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"id":"resp1","model":"sonar","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"id":"resp1","model":"sonar","choices":[{"index":0,"finish_reason":"stop","delta":{}}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		flusher, _ := w.(http.Flusher)
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", &ClientOptions{BaseURL: server.URL})
+
+	var deltas []string
+	req := ChatCompletionRequest{
+		Model:    "sonar",
+		Messages: []Message{{Role: MessageRoleUser, Content: "hi"}},
+		Stream: func(delta ChatCompletionResponse) {
+			for _, choice := range delta.Choices {
+				deltas = append(deltas, choice.Delta.Content)
+			}
+		},
+	}
+
+	resp, err := client.ChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ChatCompletion: %v", err)
+	}
+
+	if resp == nil {
+		t.Fatal("expected a non-nil fully-assembled response")
+	}
+	if got, want := resp.Choices[0].Message.Content, "Hello"; got != want {
+		t.Errorf("got assembled content %q, want %q", got, want)
+	}
+	if got, want := resp.Choices[0].FinishReason, FinishReason("stop"); got != want {
+		t.Errorf("got finish reason %q, want %q", got, want)
+	}
+	if resp.Usage.TotalTokens != 7 {
+		t.Errorf("got total tokens %d, want 7", resp.Usage.TotalTokens)
+	}
+	if len(deltas) == 0 {
+		t.Error("expected onUpdate to be called with at least one delta")
+	}
+}
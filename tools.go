@@ -0,0 +1,241 @@
+package perplexity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tool describes a function the model may call, along with a JSON Schema
+// describing the arguments it accepts.
+type Tool struct {
+	// Type is the kind of tool being described. Currently always "function".
+	Type string `json:"type"`
+
+	// Function describes the callable itself.
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a single callable function offered to the model.
+type ToolFunction struct {
+	// Name is the function's name, as the model will refer to it.
+	Name string `json:"name"`
+
+	// Description explains what the function does and when to call it.
+	Description string `json:"description,omitempty"`
+
+	// Parameters is a JSON Schema object describing the function's arguments.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall represents a single invocation of a Tool requested by the model.
+type ToolCall struct {
+	// ID identifies this call, so a later MessageRoleTool reply can
+	// reference it via Message.ToolCallID.
+	ID string `json:"id"`
+
+	// Type is the kind of tool being called. Currently always "function".
+	Type string `json:"type"`
+
+	// Function is the function the model wants called, and its arguments.
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction identifies a called function and its arguments.
+type ToolCallFunction struct {
+	// Name is the name of the Tool being called.
+	Name string `json:"name"`
+
+	// Arguments is the JSON-encoded arguments the model produced, matching
+	// the corresponding ToolFunction.Parameters schema.
+	Arguments string `json:"arguments"`
+}
+
+// ToolFunc executes a single tool call given its raw JSON arguments.
+type ToolFunc func(args json.RawMessage) (any, error)
+
+// ToolDispatcher maps tool names to the functions that implement them.
+// Dispatch executes each ToolCall a model returned and turns the results
+// into MessageRoleTool messages, ready to be appended to the conversation
+// and sent back for the model's next turn.
+type ToolDispatcher map[string]ToolFunc
+
+// Dispatch runs each of calls through its registered ToolFunc and returns
+// one MessageRoleTool message per call, in the same order. If a call names
+// a function that isn't registered, or the function returns an error, the
+// resulting message's Content carries a JSON `{"error": "..."}` payload
+// rather than aborting the remaining calls.
+func (d ToolDispatcher) Dispatch(ctx context.Context, calls []ToolCall) ([]Message, error) {
+	messages := make([]Message, len(calls))
+
+	for i, call := range calls {
+		messages[i] = Message{
+			Role:       MessageRoleTool,
+			ToolCallID: call.ID,
+			Content:    d.invoke(call),
+		}
+	}
+
+	return messages, nil
+}
+
+func (d ToolDispatcher) invoke(call ToolCall) string {
+	fn, ok := d[call.Function.Name]
+	if !ok {
+		return errorJSON(fmt.Errorf("no tool registered for %q", call.Function.Name))
+	}
+
+	result, err := fn(json.RawMessage(call.Function.Arguments))
+	if err != nil {
+		return errorJSON(err)
+	}
+
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return errorJSON(err)
+	}
+
+	return string(resultJSON)
+}
+
+func errorJSON(err error) string {
+	b, marshalErr := json.Marshal(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+	if marshalErr != nil {
+		return `{"error":"` + err.Error() + `"}`
+	}
+	return string(b)
+}
+
+// toolSchemaPrompt renders tools as a system-prompt addendum instructing the
+// model how to request a tool call, for providers (like Perplexity) with no
+// native tool-calling support. If forced names a tool (see resolveToolChoice),
+// the model is instructed it must call that tool rather than merely being
+// permitted to.
+func toolSchemaPrompt(tools []Tool, forced string) string {
+	var b strings.Builder
+	if forced != "" {
+		fmt.Fprintf(&b, "You must call the %q tool. Respond with ONLY a JSON object of the exact form {\"tool_calls\":[{\"name\":\"<tool name>\",\"arguments\":{...}}]} and nothing else.\n\nAvailable tools:\n", forced)
+	} else {
+		b.WriteString("You have access to the following tools. To call one, respond with ONLY a JSON object of the exact form {\"tool_calls\":[{\"name\":\"<tool name>\",\"arguments\":{...}}]} and nothing else. If no tool is needed, respond normally.\n\nAvailable tools:\n")
+	}
+	for _, t := range tools {
+		fmt.Fprintf(&b, "- %s: %s\n  parameters schema: %s\n", t.Function.Name, t.Function.Description, string(t.Function.Parameters))
+	}
+	return b.String()
+}
+
+// withToolSchemaPrompt returns a copy of messages with the tool schema
+// addendum merged into the (first, or a new) system message.
+func withToolSchemaPrompt(messages []Message, tools []Tool, forced string) []Message {
+	addendum := toolSchemaPrompt(tools, forced)
+
+	out := make([]Message, len(messages))
+	copy(out, messages)
+
+	for i, m := range out {
+		if m.Role == MessageRoleSystem {
+			out[i].Content = m.Content + "\n\n" + addendum
+			return out
+		}
+	}
+
+	return append([]Message{{Role: MessageRoleSystem, Content: addendum}}, out...)
+}
+
+// resolveToolChoice interprets ChatCompletionRequest.ToolChoice against the
+// tools on offer. It reports whether tool-calling should be enforced at all
+// this turn (false for "none"), and, if ToolChoice names one of tools
+// specifically (as opposed to "auto", "", or an unrecognized value), that
+// tool's name so the model can be instructed it must be called.
+func resolveToolChoice(choice any, tools []Tool) (enforce bool, forced string) {
+	name, ok := choice.(string)
+	if !ok || name == "" || name == "auto" {
+		return true, ""
+	}
+	if name == "none" {
+		return false, ""
+	}
+	for _, t := range tools {
+		if t.Function.Name == name {
+			return true, name
+		}
+	}
+	return true, ""
+}
+
+// translateToolMessages adapts MessageRoleTool messages for providers, like
+// Perplexity, with no native notion of a tool-result turn: since the
+// provider only understands system/user/assistant, each tool result is
+// folded into a user-role message instead.
+func translateToolMessages(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		if m.Role == MessageRoleTool {
+			out[i] = Message{Role: MessageRoleUser, Content: fmt.Sprintf("Tool result (call %s): %s", m.ToolCallID, m.Content)}
+			continue
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// applyToolCallExtraction rewrites each choice in resp whose Message.Content
+// matches the tool-call convention established by toolSchemaPrompt, replacing
+// that content with the parsed ToolCalls. It's applied identically whether
+// resp came from a single unary response or was assembled by
+// handleStreamingResponse from streamed deltas.
+func applyToolCallExtraction(resp *ChatCompletionResponse, tools []Tool) {
+	for i, choice := range resp.Choices {
+		if calls, ok := extractToolCalls(choice.Message.Content, tools); ok {
+			resp.Choices[i].Message.ToolCalls = calls
+			resp.Choices[i].Message.Content = ""
+		}
+	}
+}
+
+// rawToolCalls is the JSON shape we instruct the model to reply with when it
+// wants to call a tool; see toolSchemaPrompt.
+type rawToolCalls struct {
+	ToolCalls []struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"tool_calls"`
+}
+
+// extractToolCalls attempts to parse content as a tool-call request matching
+// the convention established by toolSchemaPrompt, validating that each named
+// tool was actually offered. If content doesn't match, ok is false and
+// content should be treated as an ordinary assistant reply.
+func extractToolCalls(content string, tools []Tool) (calls []ToolCall, ok bool) {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+
+	var raw rawToolCalls
+	if err := json.Unmarshal([]byte(trimmed), &raw); err != nil || len(raw.ToolCalls) == 0 {
+		return nil, false
+	}
+
+	known := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		known[t.Function.Name] = true
+	}
+
+	calls = make([]ToolCall, 0, len(raw.ToolCalls))
+	for i, c := range raw.ToolCalls {
+		if !known[c.Name] {
+			return nil, false
+		}
+		calls = append(calls, ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     "function",
+			Function: ToolCallFunction{Name: c.Name, Arguments: string(c.Arguments)},
+		})
+	}
+
+	return calls, true
+}
@@ -0,0 +1,89 @@
+package perplexity
+
+import "testing"
+
+func TestExtractToolCallsParsesMatchingCall(t *testing.T) {
+	tools := []Tool{{Type: "function", Function: ToolFunction{Name: "get_weather"}}}
+
+	calls, ok := extractToolCalls(`{"tool_calls":[{"name":"get_weather","arguments":{"city":"Paris"}}]}`, tools)
+	if !ok {
+		t.Fatal("expected ok=true for a well-formed tool call")
+	}
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(calls))
+	}
+	if calls[0].Function.Name != "get_weather" {
+		t.Errorf("got name %q, want %q", calls[0].Function.Name, "get_weather")
+	}
+	if calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("got arguments %q", calls[0].Function.Arguments)
+	}
+}
+
+func TestExtractToolCallsRejectsUnknownTool(t *testing.T) {
+	tools := []Tool{{Type: "function", Function: ToolFunction{Name: "get_weather"}}}
+
+	_, ok := extractToolCalls(`{"tool_calls":[{"name":"delete_everything","arguments":{}}]}`, tools)
+	if ok {
+		t.Fatal("expected ok=false for a tool that wasn't offered")
+	}
+}
+
+func TestExtractToolCallsRejectsOrdinaryReply(t *testing.T) {
+	tools := []Tool{{Type: "function", Function: ToolFunction{Name: "get_weather"}}}
+
+	_, ok := extractToolCalls("It looks sunny today.", tools)
+	if ok {
+		t.Fatal("expected ok=false for a non-tool-call reply")
+	}
+}
+
+func TestExtractToolCallsRejectsMalformedJSON(t *testing.T) {
+	tools := []Tool{{Type: "function", Function: ToolFunction{Name: "get_weather"}}}
+
+	_, ok := extractToolCalls(`{"tool_calls":[{"name":`, tools)
+	if ok {
+		t.Fatal("expected ok=false for malformed JSON")
+	}
+}
+
+func TestResolveToolChoiceAuto(t *testing.T) {
+	tools := []Tool{{Function: ToolFunction{Name: "get_weather"}}}
+
+	enforce, forced := resolveToolChoice(nil, tools)
+	if !enforce || forced != "" {
+		t.Errorf("unset ToolChoice: got (%v, %q), want (true, \"\")", enforce, forced)
+	}
+
+	enforce, forced = resolveToolChoice("auto", tools)
+	if !enforce || forced != "" {
+		t.Errorf("auto: got (%v, %q), want (true, \"\")", enforce, forced)
+	}
+}
+
+func TestResolveToolChoiceNoneDisablesEnforcement(t *testing.T) {
+	tools := []Tool{{Function: ToolFunction{Name: "get_weather"}}}
+
+	enforce, forced := resolveToolChoice("none", tools)
+	if enforce || forced != "" {
+		t.Errorf("none: got (%v, %q), want (false, \"\")", enforce, forced)
+	}
+}
+
+func TestResolveToolChoiceForcesNamedTool(t *testing.T) {
+	tools := []Tool{{Function: ToolFunction{Name: "get_weather"}}, {Function: ToolFunction{Name: "search"}}}
+
+	enforce, forced := resolveToolChoice("search", tools)
+	if !enforce || forced != "search" {
+		t.Errorf("got (%v, %q), want (true, \"search\")", enforce, forced)
+	}
+}
+
+func TestResolveToolChoiceUnknownToolNameFallsBackToAuto(t *testing.T) {
+	tools := []Tool{{Function: ToolFunction{Name: "get_weather"}}}
+
+	enforce, forced := resolveToolChoice("not_a_real_tool", tools)
+	if !enforce || forced != "" {
+		t.Errorf("got (%v, %q), want (true, \"\")", enforce, forced)
+	}
+}